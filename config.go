@@ -3,6 +3,7 @@ package tfjson
 import (
 	"encoding/json"
 	"errors"
+	"sort"
 )
 
 // Config represents the complete configuration source
@@ -26,6 +27,45 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// MarshalJSON implements json.Marshaler for Config. RootModule, and
+// any modules reachable through its ModuleCalls, are emitted with
+// their Resources sorted by Address, matching the ordering Terraform
+// itself uses when writing configuration JSON.
+func (c Config) MarshalJSON() ([]byte, error) {
+	type alias Config
+	cp := alias(c)
+	cp.RootModule = sortedConfigModule(c.RootModule)
+	return json.Marshal(cp)
+}
+
+// sortedConfigModule returns a copy of m with Resources sorted by
+// Address, recursively applied to every module reachable through
+// ModuleCalls. m itself is left untouched.
+func sortedConfigModule(m *ConfigModule) *ConfigModule {
+	if m == nil {
+		return nil
+	}
+
+	cp := *m
+
+	if len(m.Resources) > 0 {
+		cp.Resources = append([]ConfigResource(nil), m.Resources...)
+		sort.Slice(cp.Resources, func(i, j int) bool {
+			return cp.Resources[i].Address < cp.Resources[j].Address
+		})
+	}
+
+	if len(m.ModuleCalls) > 0 {
+		cp.ModuleCalls = make(map[string]ModuleCall, len(m.ModuleCalls))
+		for k, call := range m.ModuleCalls {
+			call.Module = sortedConfigModule(call.Module)
+			cp.ModuleCalls[k] = call
+		}
+	}
+
+	return &cp
+}
+
 // ProviderConfig describes a provider configuration instance.
 type ProviderConfig struct {
 	// The name of the provider, ie: "aws".
@@ -93,7 +133,7 @@ type ConfigResource struct {
 
 	// Any non-special configuration values in the resource, indexed by
 	// key.
-	Expressions map[string]Expression
+	Expressions map[string]Expression `json:"-"`
 
 	// The resource's configuration schema version. With access to the
 	// specific Terraform provider for this resource, this can be used
@@ -115,10 +155,15 @@ type ConfigResource struct {
 
 // UnmarshalJSON implements json.Unmarshaler for ConfigResource.
 func (r *ConfigResource) UnmarshalJSON(b []byte) error {
-	if err := json.Unmarshal(b, &r); err != nil {
+	type alias ConfigResource
+
+	var a alias
+	if err := json.Unmarshal(b, &a); err != nil {
 		return err
 	}
 
+	*r = ConfigResource(a)
+
 	r.Expressions = make(map[string]Expression)
 	for k, raw := range r.RawExpressions {
 		expr, err := unmarshalExpression(raw)
@@ -133,6 +178,30 @@ func (r *ConfigResource) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON implements json.Marshaler for ConfigResource. It is the
+// inverse of UnmarshalJSON: Expressions is re-flattened into
+// RawExpressions, marshalling each value through Expression's own
+// MarshalJSON so that nested blocks are re-nested into JSON arrays
+// rather than being wrapped in an object.
+func (r ConfigResource) MarshalJSON() ([]byte, error) {
+	type alias ConfigResource
+	cp := alias(r)
+
+	if len(r.Expressions) > 0 {
+		cp.RawExpressions = make(map[string]json.RawMessage, len(r.Expressions))
+		for k, expr := range r.Expressions {
+			raw, err := json.Marshal(expr)
+			if err != nil {
+				return nil, err
+			}
+
+			cp.RawExpressions[k] = raw
+		}
+	}
+
+	return json.Marshal(cp)
+}
+
 func unmarshalExpression(raw json.RawMessage) (Expression, error) {
 	// Check to see if this is an array first. If it is, this is more
 	// than likely a list of nested blocks.
@@ -228,5 +297,33 @@ type Expression struct {
 	// If this value is a nested block in configuration, sometimes
 	// referred to as a "sub-resource", this field will contain those
 	// values, and ConstantValue and References will be blank.
-	NestedBlocks []map[string]Expression
+	NestedBlocks []map[string]Expression `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler for Expression. It is the
+// inverse of unmarshalExpression: an Expression representing nested
+// blocks is written back out as a JSON array of objects rather than
+// as an object carrying a ConstantValue/References pair.
+func (e Expression) MarshalJSON() ([]byte, error) {
+	if e.NestedBlocks != nil {
+		blocks := make([]map[string]json.RawMessage, len(e.NestedBlocks))
+		for i, block := range e.NestedBlocks {
+			rawBlock := make(map[string]json.RawMessage, len(block))
+			for k, expr := range block {
+				raw, err := json.Marshal(expr)
+				if err != nil {
+					return nil, err
+				}
+
+				rawBlock[k] = raw
+			}
+
+			blocks[i] = rawBlock
+		}
+
+		return json.Marshal(blocks)
+	}
+
+	type alias Expression
+	return json.Marshal(alias(e))
 }
@@ -0,0 +1,153 @@
+package tfjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestExpressionNestedBlockRoundTrip checks that an Expression
+// representing nested blocks (parsed via unmarshalExpression) comes
+// back out byte-for-byte equivalent through MarshalJSON.
+func TestExpressionNestedBlockRoundTrip(t *testing.T) {
+	raw := []byte(`[{"from_port":{"constant_value":80},"to_port":{"constant_value":80}}]`)
+
+	expr, err := unmarshalExpression(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := json.Marshal(expr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got, want interface{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(raw, &want); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %s, want %s", out, raw)
+	}
+}
+
+// TestConfigResourceMarshalFlattensExpressions checks that
+// ConfigResource.MarshalJSON flattens Expressions back into the
+// "expressions" key, the inverse of what UnmarshalJSON does with
+// RawExpressions.
+func TestConfigResourceMarshalFlattensExpressions(t *testing.T) {
+	r := ConfigResource{
+		Address: "aws_instance.foo",
+		Expressions: map[string]Expression{
+			"ami": {ConstantValue: "ami-123"},
+		},
+	}
+
+	out, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Address     string `json:"address"`
+		Expressions struct {
+			AMI struct {
+				ConstantValue interface{} `json:"constant_value"`
+			} `json:"ami"`
+		} `json:"expressions"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Address != r.Address {
+		t.Fatalf("got address %q, want %q", got.Address, r.Address)
+	}
+	if got.Expressions.AMI.ConstantValue != "ami-123" {
+		t.Fatalf("got %+v, want ami-123", got.Expressions.AMI.ConstantValue)
+	}
+}
+
+// TestConfigMarshalSortsResources checks that Config.MarshalJSON
+// sorts the root module's resources by address.
+func TestConfigMarshalSortsResources(t *testing.T) {
+	c := Config{
+		RootModule: &ConfigModule{
+			Resources: []ConfigResource{
+				{Address: "aws_instance.b"},
+				{Address: "aws_instance.a"},
+			},
+		},
+	}
+
+	out, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		RootModule struct {
+			Resources []struct {
+				Address string `json:"address"`
+			} `json:"resources"`
+		} `json:"root_module"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.RootModule.Resources) != 2 ||
+		got.RootModule.Resources[0].Address != "aws_instance.a" ||
+		got.RootModule.Resources[1].Address != "aws_instance.b" {
+		t.Fatalf("resources not sorted: %+v", got.RootModule.Resources)
+	}
+}
+
+// TestConfigResourceUnmarshalJSON exercises ConfigResource's
+// UnmarshalJSON through the public json.Unmarshal entrypoint (rather
+// than only via private helpers like unmarshalExpression), decoding a
+// resource with a populated expression the way a real
+// "terraform show -json" config would arrive.
+func TestConfigResourceUnmarshalJSON(t *testing.T) {
+	raw := []byte(`{"address":"aws_instance.foo","expressions":{"ami":{"constant_value":"ami-123"}}}`)
+
+	var r ConfigResource
+	if err := json.Unmarshal(raw, &r); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Address != "aws_instance.foo" {
+		t.Fatalf("got address %q, want aws_instance.foo", r.Address)
+	}
+	if r.Expressions["ami"].ConstantValue != "ami-123" {
+		t.Fatalf("got %+v, want ami-123", r.Expressions["ami"])
+	}
+	if r.RawExpressions != nil {
+		t.Fatalf("expected RawExpressions to be cleared, got %+v", r.RawExpressions)
+	}
+}
+
+// TestConfigUnmarshalJSONWithResource decodes a full Config containing
+// a populated resource through json.Unmarshal. ConfigResource's
+// UnmarshalJSON previously recursed into itself indefinitely on input
+// like this (a **ConfigResource aliasing bug), crashing with a fatal
+// stack overflow on every real-world config or plan.
+func TestConfigUnmarshalJSONWithResource(t *testing.T) {
+	raw := []byte(`{"root_module":{"resources":[{"address":"aws_instance.foo","expressions":{"ami":{"constant_value":"ami-123"}}}]}}`)
+
+	var c Config
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.RootModule == nil || len(c.RootModule.Resources) != 1 {
+		t.Fatalf("got %+v, want a single decoded resource", c.RootModule)
+	}
+	if c.RootModule.Resources[0].Address != "aws_instance.foo" {
+		t.Fatalf("got address %q, want aws_instance.foo", c.RootModule.Resources[0].Address)
+	}
+}
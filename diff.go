@@ -0,0 +1,298 @@
+package tfjson
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// sensitiveValuePlaceholder is substituted for both sides of an
+// AttributeDiff whenever either side of the underlying value is
+// marked sensitive, so that DiffPlans never leaks a redacted value.
+const sensitiveValuePlaceholder = "(sensitive value)"
+
+// PlanDiff is the structured result of comparing two plans with
+// DiffPlans.
+type PlanDiff struct {
+	// Added lists resource changes present in the second plan but not
+	// the first.
+	Added []*ResourceChange
+
+	// Removed lists resource changes present in the first plan but
+	// not the second.
+	Removed []*ResourceChange
+
+	// Changed lists resources present in both plans whose actions or
+	// before/after values differ between the two.
+	Changed []*ResourceChangeDiff
+}
+
+// ResourceChangeDiff describes how a single resource's change
+// differs between two plans.
+type ResourceChangeDiff struct {
+	// The absolute resource address, shared by both plans.
+	Address string
+
+	// The Change.Actions from the first and second plan,
+	// respectively. These are equal unless ActionsChanged is true.
+	ActionsBefore Actions
+	ActionsAfter  Actions
+
+	// BeforeDiffs holds the per-attribute differences between the
+	// two plans' Change.Before values, as JSON-pointer style paths.
+	BeforeDiffs []*AttributeDiff
+
+	// AfterDiffs holds the per-attribute differences between the two
+	// plans' Change.After values, as JSON-pointer style paths.
+	AfterDiffs []*AttributeDiff
+}
+
+// ActionsChanged reports whether ActionsBefore and ActionsAfter
+// differ.
+func (d *ResourceChangeDiff) ActionsChanged() bool {
+	return !actionsEqual(d.ActionsBefore, d.ActionsAfter)
+}
+
+// AttributeDiff describes a single value that differs between two
+// plans at a given path.
+type AttributeDiff struct {
+	// Path is a JSON-pointer style path (RFC 6901) into the value,
+	// e.g. "/tags/Name" or "/subnet_ids/0".
+	Path string
+
+	// Before and After are the differing values from the first and
+	// second plan, respectively. If either side of the value is
+	// marked sensitive, both are replaced with a fixed placeholder
+	// rather than leaking the underlying value.
+	Before interface{}
+	After  interface{}
+}
+
+// DiffPlans compares two plans and returns the resources that were
+// added, removed, or changed between them. This is the building
+// block for answering questions like "did the plan drift after a
+// rebase" or "what changed between the speculative and applied
+// plan". Diffs honor each plan's sensitivity mask (see
+// Change.BeforeSensitive / Change.AfterSensitive): a value marked
+// sensitive in either plan diffs as an opaque placeholder rather than
+// its real value.
+func DiffPlans(a, b *Plan) *PlanDiff {
+	diff := &PlanDiff{}
+
+	var aChanges, bChanges map[string]*ResourceChange
+	if a != nil {
+		aChanges = indexResourceChangesByAddress(a)
+	}
+	if b != nil {
+		bChanges = indexResourceChangesByAddress(b)
+	}
+
+	for addr, rc := range bChanges {
+		if _, ok := aChanges[addr]; !ok {
+			diff.Added = append(diff.Added, rc)
+		}
+	}
+
+	for addr, rc := range aChanges {
+		if _, ok := bChanges[addr]; !ok {
+			diff.Removed = append(diff.Removed, rc)
+		}
+	}
+
+	for addr, before := range aChanges {
+		after, ok := bChanges[addr]
+		if !ok {
+			continue
+		}
+
+		if rcDiff := diffResourceChange(addr, before, after); rcDiff != nil {
+			diff.Changed = append(diff.Changed, rcDiff)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Address < diff.Added[j].Address })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Address < diff.Removed[j].Address })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Address < diff.Changed[j].Address })
+
+	return diff
+}
+
+func indexResourceChangesByAddress(p *Plan) map[string]*ResourceChange {
+	index := make(map[string]*ResourceChange, len(p.ResourceChanges))
+	for _, rc := range p.ResourceChanges {
+		if rc == nil {
+			continue
+		}
+
+		index[rc.Address] = rc
+	}
+
+	return index
+}
+
+func diffResourceChange(addr string, before, after *ResourceChange) *ResourceChangeDiff {
+	var beforeActions, afterActions Actions
+	var beforeBefore, beforeAfter, afterBefore, afterAfter interface{}
+	var beforeBeforeSensitive, beforeAfterSensitive, afterBeforeSensitive, afterAfterSensitive interface{}
+
+	if before.Change != nil {
+		beforeActions = before.Change.Actions
+		beforeBefore = before.Change.Before
+		beforeAfter = before.Change.After
+		beforeBeforeSensitive = before.Change.BeforeSensitive
+		beforeAfterSensitive = before.Change.AfterSensitive
+	}
+	if after.Change != nil {
+		afterActions = after.Change.Actions
+		afterBefore = after.Change.Before
+		afterAfter = after.Change.After
+		afterBeforeSensitive = after.Change.BeforeSensitive
+		afterAfterSensitive = after.Change.AfterSensitive
+	}
+
+	beforeDiffs := diffValues("", beforeBefore, afterBefore, beforeBeforeSensitive, afterBeforeSensitive)
+	afterDiffs := diffValues("", beforeAfter, afterAfter, beforeAfterSensitive, afterAfterSensitive)
+
+	if actionsEqual(beforeActions, afterActions) && len(beforeDiffs) == 0 && len(afterDiffs) == 0 {
+		return nil
+	}
+
+	return &ResourceChangeDiff{
+		Address:       addr,
+		ActionsBefore: beforeActions,
+		ActionsAfter:  afterActions,
+		BeforeDiffs:   beforeDiffs,
+		AfterDiffs:    afterDiffs,
+	}
+}
+
+func actionsEqual(a, b Actions) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// diffValues recursively walks two values in lockstep, reporting an
+// AttributeDiff for every path at which they differ. Maps are walked
+// by key and lists by index; any other mismatch (including a type
+// change) is reported as a single leaf diff at the current path.
+func diffValues(path string, before, after, beforeSensitive, afterSensitive interface{}) []*AttributeDiff {
+	if reflect.DeepEqual(before, after) {
+		return nil
+	}
+
+	if isSensitiveMaskNode(beforeSensitive) || isSensitiveMaskNode(afterSensitive) {
+		return []*AttributeDiff{{Path: path, Before: sensitiveValuePlaceholder, After: sensitiveValuePlaceholder}}
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if beforeIsMap && afterIsMap {
+		return diffMaps(path, beforeMap, afterMap, beforeSensitive, afterSensitive)
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	if beforeIsList && afterIsList {
+		return diffLists(path, beforeList, afterList, beforeSensitive, afterSensitive)
+	}
+
+	return []*AttributeDiff{{Path: path, Before: before, After: after}}
+}
+
+func diffMaps(path string, before, after map[string]interface{}, beforeSensitive, afterSensitive interface{}) []*AttributeDiff {
+	keys := make(map[string]bool, len(before)+len(after))
+	for k := range before {
+		keys[k] = true
+	}
+	for k := range after {
+		keys[k] = true
+	}
+
+	sortedKeys := make([]string, 0, len(keys))
+	for k := range keys {
+		sortedKeys = append(sortedKeys, k)
+	}
+	sort.Strings(sortedKeys)
+
+	var diffs []*AttributeDiff
+	for _, k := range sortedKeys {
+		diffs = append(diffs, diffValues(
+			path+"/"+jsonPointerEscape(k),
+			before[k],
+			after[k],
+			sensitiveMaskChild(beforeSensitive, k),
+			sensitiveMaskChild(afterSensitive, k),
+		)...)
+	}
+
+	return diffs
+}
+
+func diffLists(path string, before, after []interface{}, beforeSensitive, afterSensitive interface{}) []*AttributeDiff {
+	n := len(before)
+	if len(after) > n {
+		n = len(after)
+	}
+
+	var diffs []*AttributeDiff
+	for i := 0; i < n; i++ {
+		var b, a interface{}
+		if i < len(before) {
+			b = before[i]
+		}
+		if i < len(after) {
+			a = after[i]
+		}
+
+		diffs = append(diffs, diffValues(
+			fmt.Sprintf("%s/%d", path, i),
+			b,
+			a,
+			sensitiveMaskChildIndex(beforeSensitive, i),
+			sensitiveMaskChildIndex(afterSensitive, i),
+		)...)
+	}
+
+	return diffs
+}
+
+func isSensitiveMaskNode(mask interface{}) bool {
+	b, ok := mask.(bool)
+	return ok && b
+}
+
+func sensitiveMaskChild(mask interface{}, key string) interface{} {
+	m, ok := mask.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	return m[key]
+}
+
+func sensitiveMaskChildIndex(mask interface{}, index int) interface{} {
+	s, ok := mask.([]interface{})
+	if !ok || index < 0 || index >= len(s) {
+		return nil
+	}
+
+	return s[index]
+}
+
+// jsonPointerEscape escapes a single JSON-pointer reference token per
+// RFC 6901 (`~` becomes `~0`, `/` becomes `~1`).
+func jsonPointerEscape(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return token
+}
@@ -0,0 +1,110 @@
+package tfjson
+
+import "testing"
+
+func TestDiffPlansAddedAndRemoved(t *testing.T) {
+	a := &Plan{ResourceChanges: []*ResourceChange{{Address: "aws_instance.old"}}}
+	b := &Plan{ResourceChanges: []*ResourceChange{{Address: "aws_instance.new"}}}
+
+	diff := DiffPlans(a, b)
+
+	if len(diff.Added) != 1 || diff.Added[0].Address != "aws_instance.new" {
+		t.Fatalf("got Added %v, want [aws_instance.new]", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Address != "aws_instance.old" {
+		t.Fatalf("got Removed %v, want [aws_instance.old]", diff.Removed)
+	}
+	if len(diff.Changed) != 0 {
+		t.Fatalf("expected no changed resources, got %v", diff.Changed)
+	}
+}
+
+func TestDiffPlansChangedAttribute(t *testing.T) {
+	a := &Plan{ResourceChanges: []*ResourceChange{{
+		Address: "aws_instance.web",
+		Change: &Change{
+			Actions: Actions{Update},
+			After:   map[string]interface{}{"ami": "ami-old"},
+		},
+	}}}
+	b := &Plan{ResourceChanges: []*ResourceChange{{
+		Address: "aws_instance.web",
+		Change: &Change{
+			Actions: Actions{Update},
+			After:   map[string]interface{}{"ami": "ami-new"},
+		},
+	}}}
+
+	diff := DiffPlans(a, b)
+
+	if len(diff.Changed) != 1 {
+		t.Fatalf("got %d changed resources, want 1", len(diff.Changed))
+	}
+
+	rcDiff := diff.Changed[0]
+	if len(rcDiff.AfterDiffs) != 1 || rcDiff.AfterDiffs[0].Path != "/ami" {
+		t.Fatalf("got AfterDiffs %+v, want a single diff at /ami", rcDiff.AfterDiffs)
+	}
+	if rcDiff.AfterDiffs[0].Before != "ami-old" || rcDiff.AfterDiffs[0].After != "ami-new" {
+		t.Fatalf("got %+v, want ami-old -> ami-new", rcDiff.AfterDiffs[0])
+	}
+}
+
+func TestDiffPlansRedactsSensitiveLeaf(t *testing.T) {
+	a := &Plan{ResourceChanges: []*ResourceChange{{
+		Address: "aws_db_instance.main",
+		Change: &Change{
+			Actions:        Actions{Update},
+			After:          map[string]interface{}{"password": "old-secret"},
+			AfterSensitive: map[string]interface{}{"password": true},
+		},
+	}}}
+	b := &Plan{ResourceChanges: []*ResourceChange{{
+		Address: "aws_db_instance.main",
+		Change: &Change{
+			Actions:        Actions{Update},
+			After:          map[string]interface{}{"password": "new-secret"},
+			AfterSensitive: map[string]interface{}{"password": true},
+		},
+	}}}
+
+	diff := DiffPlans(a, b)
+
+	if len(diff.Changed) != 1 {
+		t.Fatalf("got %d changed resources, want 1", len(diff.Changed))
+	}
+
+	rcDiff := diff.Changed[0]
+	if len(rcDiff.AfterDiffs) != 1 {
+		t.Fatalf("got AfterDiffs %+v, want a single diff", rcDiff.AfterDiffs)
+	}
+	if rcDiff.AfterDiffs[0].Before != sensitiveValuePlaceholder || rcDiff.AfterDiffs[0].After != sensitiveValuePlaceholder {
+		t.Fatalf("got %+v, want both sides redacted", rcDiff.AfterDiffs[0])
+	}
+}
+
+func TestDiffPlansActionsChanged(t *testing.T) {
+	a := &Plan{ResourceChanges: []*ResourceChange{{
+		Address: "aws_instance.web",
+		Change:  &Change{Actions: Actions{NoOp}},
+	}}}
+	b := &Plan{ResourceChanges: []*ResourceChange{{
+		Address: "aws_instance.web",
+		Change:  &Change{Actions: Actions{Update}},
+	}}}
+
+	diff := DiffPlans(a, b)
+
+	if len(diff.Changed) != 1 {
+		t.Fatalf("got %d changed resources, want 1", len(diff.Changed))
+	}
+	if !diff.Changed[0].ActionsChanged() {
+		t.Fatal("expected ActionsChanged to report true")
+	}
+}
+
+func TestJSONPointerEscape(t *testing.T) {
+	if got := jsonPointerEscape("a/b~c"); got != "a~1b~0c" {
+		t.Fatalf("got %q, want %q", got, "a~1b~0c")
+	}
+}
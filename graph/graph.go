@@ -0,0 +1,227 @@
+// Package graph builds a reference/dependency graph out of the
+// Expression.References data found throughout a tfjson.Config or
+// tfjson.Plan, so that callers don't have to walk expressions by
+// hand to answer questions like "what does this resource depend on"
+// or "what would be affected if this resource changed".
+package graph
+
+import (
+	"fmt"
+
+	tfjson "github.com/davedotdev/terraform-json"
+)
+
+// Graph is a directed graph of absolute resource (and module,
+// variable, local) addresses, where an edge from A to B means that A
+// references B: B must be known before A can be evaluated.
+type Graph struct {
+	nodes map[string]bool
+	// edges maps an address to the set of addresses it references.
+	edges map[string]map[string]bool
+	// reverse maps an address to the set of addresses that reference it.
+	reverse map[string]map[string]bool
+}
+
+func newGraph() *Graph {
+	return &Graph{
+		nodes:   make(map[string]bool),
+		edges:   make(map[string]map[string]bool),
+		reverse: make(map[string]map[string]bool),
+	}
+}
+
+func (g *Graph) addNode(addr string) {
+	if addr == "" {
+		return
+	}
+
+	g.nodes[addr] = true
+	if g.edges[addr] == nil {
+		g.edges[addr] = make(map[string]bool)
+	}
+	if g.reverse[addr] == nil {
+		g.reverse[addr] = make(map[string]bool)
+	}
+}
+
+func (g *Graph) addEdge(from, to string) {
+	if from == "" || to == "" || from == to {
+		return
+	}
+
+	g.addNode(from)
+	g.addNode(to)
+	g.edges[from][to] = true
+	g.reverse[to][from] = true
+}
+
+// Nodes returns every address known to the graph, in no particular
+// order.
+func (g *Graph) Nodes() []string {
+	nodes := make([]string, 0, len(g.nodes))
+	for addr := range g.nodes {
+		nodes = append(nodes, addr)
+	}
+
+	return nodes
+}
+
+// Ancestors returns every address that addr depends on, directly or
+// transitively. This is the set of things that must be known (and,
+// for a plan, applied) before addr itself.
+func (g *Graph) Ancestors(addr string) []string {
+	return g.walk(addr, g.edges)
+}
+
+// Descendants returns every address that depends on addr, directly
+// or transitively. This is the set of things that will be affected
+// by a change to addr.
+func (g *Graph) Descendants(addr string) []string {
+	return g.walk(addr, g.reverse)
+}
+
+func (g *Graph) walk(addr string, adjacency map[string]map[string]bool) []string {
+	visited := make(map[string]bool)
+	queue := []string{addr}
+
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		for neighbor := range adjacency[next] {
+			if visited[neighbor] {
+				continue
+			}
+
+			visited[neighbor] = true
+			queue = append(queue, neighbor)
+		}
+	}
+
+	result := make([]string, 0, len(visited))
+	for n := range visited {
+		result = append(result, n)
+	}
+
+	return result
+}
+
+// TopoSort returns every node in the graph ordered so that each
+// address appears after everything it depends on (i.e. a valid apply
+// order). It returns an error if the graph contains a cycle.
+func (g *Graph) TopoSort() ([]string, error) {
+	inDegree := make(map[string]int, len(g.nodes))
+	for addr := range g.nodes {
+		inDegree[addr] = len(g.edges[addr])
+	}
+
+	var queue []string
+	for addr, degree := range inDegree {
+		if degree == 0 {
+			queue = append(queue, addr)
+		}
+	}
+
+	result := make([]string, 0, len(g.nodes))
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		result = append(result, next)
+
+		for dependent := range g.reverse[next] {
+			inDegree[dependent]--
+			if inDegree[dependent] == 0 {
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	if len(result) != len(g.nodes) {
+		return nil, fmt.Errorf("graph: cycle detected, cannot topologically sort")
+	}
+
+	return result, nil
+}
+
+// BuildConfigGraph walks a tfjson.Config, including every module
+// reachable through ModuleCall.Module, and returns the resulting
+// reference graph. Every resource (data sources included) and module
+// call is a node; variable and local addresses become nodes only when
+// something references them. Edges come from parsing every
+// Expression.References entry found in resources and module calls.
+func BuildConfigGraph(c *tfjson.Config) *Graph {
+	g := newGraph()
+
+	if c == nil || c.RootModule == nil {
+		return g
+	}
+
+	addConfigModule(g, "", c.RootModule)
+	return g
+}
+
+func addConfigModule(g *Graph, prefix string, m *tfjson.ConfigModule) {
+	for _, r := range m.Resources {
+		addr := tfjson.JoinAddress(prefix, r.Address)
+		g.addNode(addr)
+
+		for _, ref := range tfjson.ResourceReferences(r) {
+			if target := tfjson.ResolveReference(prefix, ref); target != "" {
+				g.addEdge(addr, target)
+			}
+		}
+	}
+
+	for name, call := range m.ModuleCalls {
+		addr := tfjson.JoinAddress(prefix, "module."+name)
+		g.addNode(addr)
+
+		refs := tfjson.ExpressionMapReferences(call.Expressions)
+		if call.CountExpression != nil {
+			refs = append(refs, tfjson.ExpressionReferences(*call.CountExpression)...)
+		}
+		if call.ForEachExpression != nil {
+			refs = append(refs, tfjson.ExpressionReferences(*call.ForEachExpression)...)
+		}
+
+		for _, ref := range refs {
+			if target := tfjson.ResolveReference(prefix, ref); target != "" {
+				g.addEdge(addr, target)
+			}
+		}
+
+		if call.Module != nil {
+			addConfigModule(g, addr, call.Module)
+		}
+	}
+}
+
+// BuildPlanGraph builds the reference graph for a tfjson.Plan. It is
+// built from the plan's embedded Config, then extended with an edge
+// from every instance-level ResourceChange.Address (as produced by
+// "count" or "for_each", e.g. aws_instance.foo[0]) to its
+// configuration-level address, so that callers can look up either
+// form.
+func BuildPlanGraph(p *tfjson.Plan) *Graph {
+	if p == nil {
+		return newGraph()
+	}
+
+	g := BuildConfigGraph(p.Config)
+
+	for _, rc := range p.ResourceChanges {
+		if rc == nil {
+			continue
+		}
+
+		configAddr := tfjson.StripInstanceKey(rc.Address)
+		if configAddr == rc.Address {
+			g.addNode(rc.Address)
+			continue
+		}
+
+		g.addEdge(rc.Address, configAddr)
+	}
+
+	return g
+}
@@ -0,0 +1,99 @@
+package graph
+
+import (
+	"testing"
+
+	tfjson "github.com/davedotdev/terraform-json"
+)
+
+func testConfig() *tfjson.Config {
+	return &tfjson.Config{
+		RootModule: &tfjson.ConfigModule{
+			Resources: []tfjson.ConfigResource{
+				{
+					Address: "aws_instance.web",
+					Expressions: map[string]tfjson.Expression{
+						"subnet_id": {References: []string{"aws_subnet.main.id"}},
+					},
+				},
+				{
+					Address: "aws_subnet.main",
+				},
+			},
+		},
+	}
+}
+
+func TestBuildConfigGraphAncestorsDescendants(t *testing.T) {
+	g := BuildConfigGraph(testConfig())
+
+	ancestors := g.Ancestors("aws_instance.web")
+	if len(ancestors) != 1 || ancestors[0] != "aws_subnet.main" {
+		t.Fatalf("got ancestors %v, want [aws_subnet.main]", ancestors)
+	}
+
+	descendants := g.Descendants("aws_subnet.main")
+	if len(descendants) != 1 || descendants[0] != "aws_instance.web" {
+		t.Fatalf("got descendants %v, want [aws_instance.web]", descendants)
+	}
+}
+
+func TestBuildConfigGraphTopoSort(t *testing.T) {
+	g := BuildConfigGraph(testConfig())
+
+	order, err := g.TopoSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := make(map[string]int, len(order))
+	for i, addr := range order {
+		index[addr] = i
+	}
+
+	if index["aws_subnet.main"] >= index["aws_instance.web"] {
+		t.Fatalf("expected aws_subnet.main before aws_instance.web, got order %v", order)
+	}
+}
+
+func TestTopoSortDetectsCycle(t *testing.T) {
+	g := newGraph()
+	g.addEdge("a", "b")
+	g.addEdge("b", "a")
+
+	if _, err := g.TopoSort(); err == nil {
+		t.Fatal("expected an error for a cyclic graph")
+	}
+}
+
+func TestBuildPlanGraphLinksInstanceToConfigAddress(t *testing.T) {
+	p := &tfjson.Plan{
+		Config: testConfig(),
+		ResourceChanges: []*tfjson.ResourceChange{
+			{Address: "aws_instance.web[0]"},
+		},
+	}
+
+	g := BuildPlanGraph(p)
+
+	ancestors := g.Ancestors("aws_instance.web[0]")
+	found := false
+	for _, a := range ancestors {
+		if a == "aws_instance.web" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected aws_instance.web[0] to link to aws_instance.web, got %v", ancestors)
+	}
+}
+
+func TestBuildConfigGraphNilInputs(t *testing.T) {
+	if g := BuildConfigGraph(nil); len(g.Nodes()) != 0 {
+		t.Fatalf("expected empty graph for nil config, got %v", g.Nodes())
+	}
+
+	if g := BuildPlanGraph(nil); len(g.Nodes()) != 0 {
+		t.Fatalf("expected empty graph for nil plan, got %v", g.Nodes())
+	}
+}
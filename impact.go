@@ -0,0 +1,210 @@
+package tfjson
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ImpactEntry aggregates the resource changes of a single resource
+// type, as computed by Plan.ImpactSummary.
+type ImpactEntry struct {
+	// Per-action counts of resource changes of this type.
+	Create  int
+	Update  int
+	Delete  int
+	Replace int
+	Read    int
+	NoOp    int
+
+	// HighRiskAddresses lists the addresses of changes of this type
+	// flagged as high risk: a delete of a resource that other
+	// resources still depend on, or a replace whose ActionReason is
+	// ReplaceBecauseCannotUpdate.
+	HighRiskAddresses []string
+}
+
+// BlastRadius returns every ResourceChange in the plan whose
+// configuration transitively references address, i.e. everything
+// that will re-plan or re-apply as a consequence of a change to
+// address. Multiple instances of a "count" or "for_each" resource
+// (e.g. aws_instance.foo[0] and aws_instance.foo[1]) are treated as a
+// single dependency node. It returns an error if address does not
+// match any resource change in the plan.
+func (p *Plan) BlastRadius(address string) ([]*ResourceChange, error) {
+	if p == nil {
+		return nil, errors.New("plan is nil")
+	}
+
+	byBase := p.resourceChangesByBaseAddress()
+
+	base := StripInstanceKey(address)
+	if _, ok := byBase[base]; !ok {
+		return nil, fmt.Errorf("address %q not found in plan", address)
+	}
+
+	deps := p.dependencyGraph()
+
+	descendants := make(map[string]bool)
+	queue := []string{base}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+
+		for dependent := range deps.dependents[next] {
+			if descendants[dependent] {
+				continue
+			}
+
+			descendants[dependent] = true
+			queue = append(queue, dependent)
+		}
+	}
+
+	var result []*ResourceChange
+	for addr := range descendants {
+		result = append(result, byBase[addr]...)
+	}
+
+	return result, nil
+}
+
+// ImpactSummary aggregates the plan's resource changes by resource
+// type, reporting per-action counts and flagging high-risk changes: a
+// delete of a resource that other resources still depend on, or a
+// replace whose ActionReason is ReplaceBecauseCannotUpdate.
+func (p *Plan) ImpactSummary() map[string]*ImpactEntry {
+	summary := make(map[string]*ImpactEntry)
+	if p == nil {
+		return summary
+	}
+
+	deps := p.dependencyGraph()
+
+	for _, rc := range p.ResourceChanges {
+		if rc == nil || rc.Change == nil {
+			continue
+		}
+
+		entry, ok := summary[rc.Type]
+		if !ok {
+			entry = &ImpactEntry{}
+			summary[rc.Type] = entry
+		}
+
+		actions := rc.Change.Actions
+		switch {
+		case actions.Replace():
+			entry.Replace++
+		case actions.Delete():
+			entry.Delete++
+		case actions.Create():
+			entry.Create++
+		case actions.Update():
+			entry.Update++
+		case actions.Read():
+			entry.Read++
+		default:
+			entry.NoOp++
+		}
+
+		base := StripInstanceKey(rc.Address)
+		highRisk := (actions.Delete() && len(deps.dependents[base]) > 0) ||
+			(actions.Replace() && rc.ActionReason == ReplaceBecauseCannotUpdate)
+
+		if highRisk {
+			entry.HighRiskAddresses = append(entry.HighRiskAddresses, rc.Address)
+		}
+	}
+
+	return summary
+}
+
+// resourceChangesByBaseAddress indexes the plan's resource changes by
+// their base address (with any "count"/"for_each" instance key
+// stripped), so that multiple instances of the same resource share a
+// single dependency graph node.
+func (p *Plan) resourceChangesByBaseAddress() map[string][]*ResourceChange {
+	byBase := make(map[string][]*ResourceChange)
+	for _, rc := range p.ResourceChanges {
+		if rc == nil {
+			continue
+		}
+
+		base := StripInstanceKey(rc.Address)
+		byBase[base] = append(byBase[base], rc)
+	}
+
+	return byBase
+}
+
+// planDependencyGraph is a minimal, unexported directed graph of base
+// resource addresses to the addresses that depend on them. It exists
+// to support BlastRadius and ImpactSummary. Unlike the tfjson/graph
+// subsystem (which this package cannot import back, since graph
+// already depends on tfjson), it only tracks the reverse edges those
+// two methods need; both are built from the same ResourceReferences /
+// ResolveReference / JoinAddress primitives in refs.go.
+type planDependencyGraph struct {
+	// dependents maps an address to the set of addresses that
+	// reference it.
+	dependents map[string]map[string]bool
+}
+
+func (p *Plan) dependencyGraph() *planDependencyGraph {
+	g := &planDependencyGraph{dependents: make(map[string]map[string]bool)}
+
+	if p.Config == nil || p.Config.RootModule == nil {
+		return g
+	}
+
+	addPlanConfigModuleEdges(g, "", p.Config.RootModule)
+	return g
+}
+
+func addPlanConfigModuleEdges(g *planDependencyGraph, prefix string, m *ConfigModule) {
+	for _, r := range m.Resources {
+		addr := JoinAddress(prefix, r.Address)
+
+		for _, ref := range ResourceReferences(r) {
+			if target := ResolveReference(prefix, ref); target != "" {
+				g.addEdge(addr, target)
+			}
+		}
+	}
+
+	for name, call := range m.ModuleCalls {
+		addr := JoinAddress(prefix, "module."+name)
+
+		refs := ExpressionMapReferences(call.Expressions)
+		if call.CountExpression != nil {
+			refs = append(refs, ExpressionReferences(*call.CountExpression)...)
+		}
+		if call.ForEachExpression != nil {
+			refs = append(refs, ExpressionReferences(*call.ForEachExpression)...)
+		}
+
+		for _, ref := range refs {
+			if target := ResolveReference(prefix, ref); target != "" {
+				g.addEdge(addr, target)
+			}
+		}
+
+		if call.Module != nil {
+			addPlanConfigModuleEdges(g, addr, call.Module)
+		}
+	}
+}
+
+// addEdge records that from references to, i.e. to has from as a
+// dependent.
+func (g *planDependencyGraph) addEdge(from, to string) {
+	if from == "" || to == "" || from == to {
+		return
+	}
+
+	if g.dependents[to] == nil {
+		g.dependents[to] = make(map[string]bool)
+	}
+
+	g.dependents[to][from] = true
+}
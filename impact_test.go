@@ -0,0 +1,91 @@
+package tfjson
+
+import "testing"
+
+func testImpactPlan() *Plan {
+	return &Plan{
+		Config: &Config{
+			RootModule: &ConfigModule{
+				Resources: []ConfigResource{
+					{
+						Address: "aws_instance.web",
+						Expressions: map[string]Expression{
+							"subnet_id": {References: []string{"aws_subnet.main.id"}},
+						},
+					},
+					{Address: "aws_subnet.main"},
+				},
+			},
+		},
+		ResourceChanges: []*ResourceChange{
+			{
+				Address: "aws_subnet.main",
+				Type:    "aws_subnet",
+				Change:  &Change{Actions: Actions{Delete}},
+			},
+			{
+				Address: "aws_instance.web",
+				Type:    "aws_instance",
+				Change:  &Change{Actions: Actions{NoOp}},
+			},
+		},
+	}
+}
+
+func TestBlastRadius(t *testing.T) {
+	p := testImpactPlan()
+
+	affected, err := p.BlastRadius("aws_subnet.main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(affected) != 1 || affected[0].Address != "aws_instance.web" {
+		t.Fatalf("got %v, want [aws_instance.web]", affected)
+	}
+}
+
+func TestBlastRadiusUnknownAddress(t *testing.T) {
+	p := testImpactPlan()
+
+	if _, err := p.BlastRadius("aws_instance.missing"); err == nil {
+		t.Fatal("expected an error for an address not present in the plan")
+	}
+}
+
+func TestImpactSummaryFlagsHighRiskDelete(t *testing.T) {
+	p := testImpactPlan()
+
+	summary := p.ImpactSummary()
+
+	entry, ok := summary["aws_subnet"]
+	if !ok {
+		t.Fatal("expected an aws_subnet entry in the summary")
+	}
+
+	if entry.Delete != 1 {
+		t.Fatalf("got Delete %d, want 1", entry.Delete)
+	}
+
+	if len(entry.HighRiskAddresses) != 1 || entry.HighRiskAddresses[0] != "aws_subnet.main" {
+		t.Fatalf("got HighRiskAddresses %v, want [aws_subnet.main]", entry.HighRiskAddresses)
+	}
+}
+
+func TestImpactSummaryCountsNoOp(t *testing.T) {
+	p := testImpactPlan()
+
+	summary := p.ImpactSummary()
+
+	entry, ok := summary["aws_instance"]
+	if !ok {
+		t.Fatal("expected an aws_instance entry in the summary")
+	}
+
+	if entry.NoOp != 1 {
+		t.Fatalf("got NoOp %d, want 1", entry.NoOp)
+	}
+	if len(entry.HighRiskAddresses) != 0 {
+		t.Fatalf("expected no high risk addresses, got %v", entry.HighRiskAddresses)
+	}
+}
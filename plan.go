@@ -4,11 +4,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // PlanFormatVersion is the version of the JSON plan format that is
 // supported by this package.
-const PlanFormatVersion = "0.1"
+//
+// This is the latest version known to be supported. Older format
+// versions back to PlanFormatVersionMin are also accepted by
+// Plan.Validate, as Terraform has historically made only additive
+// changes to this format.
+const PlanFormatVersion = "1.2"
+
+// PlanFormatVersionMin is the oldest version of the JSON plan format
+// that is supported by this package.
+const PlanFormatVersionMin = "0.1"
 
 // ResourceMode is a string representation of the resource type found
 // in certain fields in the plan.
@@ -66,13 +78,189 @@ func (p *Plan) Validate() error {
 		return errors.New("unexpected plan input, format version is missing")
 	}
 
-	if PlanFormatVersion != p.FormatVersion {
-		return fmt.Errorf("unsupported plan format version: expected %q, got %q", PlanFormatVersion, p.FormatVersion)
+	if !planFormatVersionSupported(p.FormatVersion) {
+		return fmt.Errorf("unsupported plan format version: expected version between %q and %q, got %q", PlanFormatVersionMin, PlanFormatVersion, p.FormatVersion)
 	}
 
 	return nil
 }
 
+// UnmarshalJSON implements json.Unmarshaler for Plan. It runs
+// PropagateOutputSensitivity once the plan has been decoded, so that
+// callers unmarshalling plan JSON through the usual json.Unmarshal /
+// json.Decoder path get config-declared output redaction for free,
+// without having to know to call it themselves.
+func (p *Plan) UnmarshalJSON(b []byte) error {
+	type alias Plan
+
+	var a alias
+	if err := json.Unmarshal(b, &a); err != nil {
+		return err
+	}
+
+	*p = Plan(a)
+	p.PropagateOutputSensitivity()
+	return nil
+}
+
+// PropagateOutputSensitivity marks the AfterSensitive (and, for
+// destroy actions, BeforeSensitive) mask of each entry in
+// OutputChanges as wholly sensitive if the corresponding output is
+// declared sensitive in Config. Terraform itself sets the
+// sensitivity mask based on whether any value contributing to the
+// output was sensitive, but that doesn't always capture an output
+// that was only declared sensitive in configuration without a
+// sensitive source value.
+//
+// UnmarshalJSON calls this automatically, so most callers never need
+// to call it directly. It is still exported for the case of a Plan
+// assembled or mutated in Go rather than decoded from JSON.
+func (p *Plan) PropagateOutputSensitivity() {
+	if p == nil || p.Config == nil || p.Config.RootModule == nil {
+		return
+	}
+
+	for name, change := range p.OutputChanges {
+		output, ok := p.Config.RootModule.Outputs[name]
+		if !ok || !output.Sensitive || change == nil {
+			continue
+		}
+
+		if change.Actions.Delete() {
+			change.BeforeSensitive = true
+		}
+		change.AfterSensitive = true
+	}
+}
+
+// MarshalJSON implements json.Marshaler for Plan. ResourceChanges is
+// emitted sorted by Address, matching the ordering Terraform itself
+// uses when writing plan JSON, so that re-serialized plans are
+// byte-for-byte comparable regardless of the order changes were
+// appended to the in-memory Plan.
+func (p Plan) MarshalJSON() ([]byte, error) {
+	type alias Plan
+	cp := alias(p)
+
+	if len(p.ResourceChanges) > 0 {
+		cp.ResourceChanges = append([]*ResourceChange(nil), p.ResourceChanges...)
+		sort.Slice(cp.ResourceChanges, func(i, j int) bool {
+			return cp.ResourceChanges[i].Address < cp.ResourceChanges[j].Address
+		})
+	}
+
+	return json.Marshal(cp)
+}
+
+// planFormatVersionSupported returns true if the supplied format
+// version falls within the inclusive range of
+// [PlanFormatVersionMin, PlanFormatVersion].
+func planFormatVersionSupported(version string) bool {
+	min, err := parsePlanFormatVersion(PlanFormatVersionMin)
+	if err != nil {
+		return false
+	}
+
+	max, err := parsePlanFormatVersion(PlanFormatVersion)
+	if err != nil {
+		return false
+	}
+
+	v, err := parsePlanFormatVersion(version)
+	if err != nil {
+		return false
+	}
+
+	atLeastMin := v[0] > min[0] || (v[0] == min[0] && v[1] >= min[1])
+	atMostMax := v[0] < max[0] || (v[0] == max[0] && v[1] <= max[1])
+
+	return atLeastMin && atMostMax
+}
+
+// parsePlanFormatVersion parses a "major.minor" format version string
+// into its two numeric components.
+func parsePlanFormatVersion(version string) ([2]int, error) {
+	var result [2]int
+
+	parts := strings.SplitN(version, ".", 2)
+	if len(parts) != 2 {
+		return result, fmt.Errorf("invalid format version %q", version)
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return result, fmt.Errorf("invalid format version %q: %w", version, err)
+	}
+
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return result, fmt.Errorf("invalid format version %q: %w", version, err)
+	}
+
+	result[0], result[1] = major, minor
+	return result, nil
+}
+
+// ActionReason is a string that describes the reason why a
+// ResourceChange has the Change.Actions that it does, for actions
+// that are not simply a direct consequence of a diff between
+// configuration and state.
+type ActionReason string
+
+const (
+	// ReplaceBecauseCannotUpdate is set when the resource is being
+	// replaced because a changed argument cannot be updated in-place.
+	ReplaceBecauseCannotUpdate ActionReason = "replace_because_cannot_update"
+
+	// ReplaceBecauseTainted is set when the resource is being
+	// replaced because it was marked as tainted in state.
+	ReplaceBecauseTainted ActionReason = "replace_because_tainted"
+
+	// ReplaceByRequest is set when the resource is being replaced
+	// because of a user-requested replace, such as the -replace
+	// planning option.
+	ReplaceByRequest ActionReason = "replace_by_request"
+
+	// ReplaceByTriggers is set when the resource is being replaced
+	// because it was marked to be replaced by a replace_triggered_by
+	// reference in its resource definition.
+	ReplaceByTriggers ActionReason = "replace_by_triggers"
+
+	// DeleteBecauseNoResourceConfig is set when the resource is
+	// being deleted because it no longer exists in configuration and
+	// the module it belonged to is not being removed as a whole.
+	DeleteBecauseNoResourceConfig ActionReason = "delete_because_no_resource_config"
+
+	// DeleteBecauseWrongRepetition is set when the resource is being
+	// deleted because the count or for_each mode for the resource has
+	// changed.
+	DeleteBecauseWrongRepetition ActionReason = "delete_because_wrong_repetition"
+
+	// DeleteBecauseCountIndex is set when the resource is being
+	// deleted because its instance key is an integer index which is
+	// no longer in range based on the current count argument.
+	DeleteBecauseCountIndex ActionReason = "delete_because_count_index"
+
+	// DeleteBecauseEachKey is set when the resource is being deleted
+	// because its instance key is a string value which is no longer
+	// in range based on the current for_each argument.
+	DeleteBecauseEachKey ActionReason = "delete_because_each_key"
+
+	// DeleteBecauseNoModule is set when the resource is being deleted
+	// because the module it was declared in no longer exists.
+	DeleteBecauseNoModule ActionReason = "delete_because_no_module"
+
+	// ReadBecauseConfigUnknown is set when a data source is being
+	// read because one or more arguments in its configuration are
+	// not yet known.
+	ReadBecauseConfigUnknown ActionReason = "read_because_config_unknown"
+
+	// ReadBecauseDependencyPending is set when a data source is
+	// being read because it depends on a managed resource which
+	// itself has a pending change.
+	ReadBecauseDependencyPending ActionReason = "read_because_dependency_pending"
+)
+
 // ResourceChange is a description of an individual change action
 // that Terraform plans to use to move from the prior state to a new
 // state matching the configuration.
@@ -80,6 +268,12 @@ type ResourceChange struct {
 	// The absolute resource address.
 	Address string `json:"address,omitempty"`
 
+	// The previous address of this resource, if this change
+	// represents a resource that was moved, renamed via "moved"
+	// configuration blocks, or otherwise has a different address
+	// than it did in the prior state.
+	PreviousAddress string `json:"previous_address,omitempty"`
+
 	// The module portion of the above address. Omitted if the instance
 	// is in the root module.
 	ModuleAddress string `json:"module_address,omitempty"`
@@ -110,6 +304,20 @@ type ResourceChange struct {
 
 	// The data describing the change that will be made to this object.
 	Change *Change `json:"change,omitempty"`
+
+	// The reason for the change, annotating cases where the action
+	// taken is not a simple and direct consequence of a diff between
+	// the prior state and the configuration.
+	ActionReason ActionReason `json:"action_reason,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler for ResourceChange. It encodes
+// every field with the default encoding/json struct behavior, through
+// an alias type that has no MarshalJSON method of its own; it applies
+// no field-level transforms.
+func (r ResourceChange) MarshalJSON() ([]byte, error) {
+	type alias ResourceChange
+	return json.Marshal(alias(r))
 }
 
 // Change is the representation of a proposed change for an object.
@@ -126,6 +334,15 @@ type Change struct {
 	Before interface{} `json:"before,"`
 	After  interface{} `json:"after,omitempty"`
 
+	// BeforeSensitive and AfterSensitive are deep copies of Before and
+	// After, but with all primitive values replaced with a boolean
+	// mask. A true value indicates that the corresponding value (or,
+	// for maps and slices, one of its descendants) is sensitive and
+	// should be redacted before being displayed to a user. Use
+	// IsPathSensitive to query this mask for a specific value path.
+	BeforeSensitive interface{} `json:"before_sensitive,omitempty"`
+	AfterSensitive  interface{} `json:"after_sensitive,omitempty"`
+
 	// A deep object of booleans that denotes any values that are
 	// unknown in a resource. These values were previously referred to
 	// as "computed" values.
@@ -133,6 +350,71 @@ type Change struct {
 	// If the value cannot be found in this map, then its value should
 	// be available within After, so long as the operation supports it.
 	AfterUnknown interface{} `json:"after_unknown,omitempty"`
+
+	// Importing contains the import metadata for this resource
+	// instance, and is only present when this change represents an
+	// import-in-plan (generated via the "import" configuration block
+	// or the -generate-config-out planning option).
+	Importing *Importing `json:"importing,omitempty"`
+
+	// GeneratedConfig is the HCL configuration generated for this
+	// resource during an import-in-plan, in the same form produced by
+	// the -generate-config-out planning option. This is empty unless
+	// Importing is set and configuration generation was requested.
+	GeneratedConfig string `json:"generated_config,omitempty"`
+}
+
+// Importing contains the import metadata present on a Change when
+// the change is importing a new resource instance into state.
+type Importing struct {
+	// The original ID of this resource used to target it as part of
+	// planned import.
+	ID string `json:"id,omitempty"`
+}
+
+// IsPathSensitive walks the BeforeSensitive and AfterSensitive masks
+// alongside the supplied value path, and returns true if the leaf
+// that the path resolves to, or any of its ancestors, is marked
+// sensitive.
+//
+// Path elements are either a string, for indexing into an object or
+// map, or an int, for indexing into a list or tuple. A path that
+// cannot be resolved in either mask is treated as not sensitive.
+func (c *Change) IsPathSensitive(path ...interface{}) bool {
+	if c == nil {
+		return false
+	}
+
+	return isPathSensitive(c.BeforeSensitive, path) || isPathSensitive(c.AfterSensitive, path)
+}
+
+func isPathSensitive(mask interface{}, path []interface{}) bool {
+	if b, ok := mask.(bool); ok && b {
+		return true
+	}
+
+	if len(path) == 0 {
+		return false
+	}
+
+	switch key := path[0].(type) {
+	case string:
+		m, ok := mask.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		return isPathSensitive(m[key], path[1:])
+	case int:
+		s, ok := mask.([]interface{})
+		if !ok || key < 0 || key >= len(s) {
+			return false
+		}
+
+		return isPathSensitive(s[key], path[1:])
+	default:
+		return false
+	}
 }
 
 // PlanVariable is a top-level variable in the Terraform plan.
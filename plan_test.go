@@ -0,0 +1,156 @@
+package tfjson
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestPlanMarshalUnmarshalRoundTrip checks that a Plan survives a
+// Marshal/Unmarshal round trip through the new marshaller, including
+// resource changes being re-sorted by address.
+func TestPlanMarshalUnmarshalRoundTrip(t *testing.T) {
+	p := Plan{
+		FormatVersion:    "1.2",
+		TerraformVersion: "1.6.0",
+		ResourceChanges: []*ResourceChange{
+			{Address: "aws_instance.b", Type: "aws_instance", Name: "b", Change: &Change{Actions: Actions{NoOp}}},
+			{Address: "aws_instance.a", Type: "aws_instance", Name: "a", Change: &Change{Actions: Actions{Create}}},
+		},
+	}
+
+	out, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got Plan
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.FormatVersion != p.FormatVersion || got.TerraformVersion != p.TerraformVersion {
+		t.Fatalf("got %+v, want %+v", got, p)
+	}
+
+	if len(got.ResourceChanges) != 2 ||
+		got.ResourceChanges[0].Address != "aws_instance.a" ||
+		got.ResourceChanges[1].Address != "aws_instance.b" {
+		t.Fatalf("resource changes not sorted: %+v", got.ResourceChanges)
+	}
+}
+
+func TestPlanMarshalSortsResourceChanges(t *testing.T) {
+	p := Plan{
+		ResourceChanges: []*ResourceChange{
+			{Address: "b"},
+			{Address: "a"},
+		},
+	}
+
+	out, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		ResourceChanges []struct {
+			Address string `json:"address"`
+		} `json:"resource_changes"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ResourceChanges[0].Address != "a" || got.ResourceChanges[1].Address != "b" {
+		t.Fatalf("not sorted: %+v", got.ResourceChanges)
+	}
+}
+
+func TestPlanValidateVersionRange(t *testing.T) {
+	for _, v := range []string{"0.1", "0.2", "1.0", "1.1", "1.2"} {
+		p := Plan{FormatVersion: v}
+		if err := p.Validate(); err != nil {
+			t.Errorf("version %s should be supported: %v", v, err)
+		}
+	}
+
+	for _, v := range []string{"0.0", "1.3", "2.0"} {
+		p := Plan{FormatVersion: v}
+		if err := p.Validate(); err == nil {
+			t.Errorf("version %s should not be supported", v)
+		}
+	}
+}
+
+func TestPlanUnmarshalPropagatesOutputSensitivity(t *testing.T) {
+	raw := []byte(`{
+		"format_version": "1.2",
+		"configuration": {
+			"root_module": {
+				"outputs": {"db_password": {"sensitive": true}}
+			}
+		},
+		"output_changes": {
+			"db_password": {"after": "hunter2"}
+		}
+	}`)
+
+	var p Plan
+	if err := json.Unmarshal(raw, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	change := p.OutputChanges["db_password"]
+	if change.AfterSensitive != true {
+		t.Fatalf("expected AfterSensitive to be propagated, got %+v", change)
+	}
+	if change.BeforeSensitive == true {
+		t.Fatalf("expected BeforeSensitive to stay unset for a non-destroy action, got %+v", change)
+	}
+}
+
+func TestPropagateOutputSensitivitySetsBeforeOnDestroy(t *testing.T) {
+	raw := []byte(`{
+		"format_version": "1.2",
+		"configuration": {
+			"root_module": {
+				"outputs": {"db_password": {"sensitive": true}}
+			}
+		},
+		"output_changes": {
+			"db_password": {"before": "hunter2", "actions": ["delete"]}
+		}
+	}`)
+
+	var p Plan
+	if err := json.Unmarshal(raw, &p); err != nil {
+		t.Fatal(err)
+	}
+
+	change := p.OutputChanges["db_password"]
+	if change.BeforeSensitive != true {
+		t.Fatalf("expected BeforeSensitive to be propagated for a destroy action, got %+v", change)
+	}
+}
+
+func TestIsPathSensitive(t *testing.T) {
+	c := Change{
+		AfterSensitive: map[string]interface{}{
+			"a": true,
+			"b": []interface{}{false, true},
+		},
+	}
+
+	if !c.IsPathSensitive("a") {
+		t.Error("expected a to be sensitive")
+	}
+	if !c.IsPathSensitive("b", 1) {
+		t.Error("expected b[1] to be sensitive")
+	}
+	if c.IsPathSensitive("b", 0) {
+		t.Error("expected b[0] to not be sensitive")
+	}
+	if c.IsPathSensitive("c") {
+		t.Error("expected c to not be sensitive")
+	}
+}
@@ -0,0 +1,110 @@
+package tfjson
+
+import "strings"
+
+// ResourceReferences collects every reference found in a resource's
+// configuration: its attribute expressions, and its count/for_each
+// expressions.
+//
+// It is the shared primitive behind the tfjson/graph subsystem and
+// Plan.BlastRadius/Plan.ImpactSummary, both of which need to parse
+// the same reference data to build a dependency graph.
+func ResourceReferences(r ConfigResource) []string {
+	refs := ExpressionMapReferences(r.Expressions)
+
+	if r.CountExpression != nil {
+		refs = append(refs, ExpressionReferences(*r.CountExpression)...)
+	}
+	if r.ForEachExpression != nil {
+		refs = append(refs, ExpressionReferences(*r.ForEachExpression)...)
+	}
+
+	return refs
+}
+
+// ExpressionMapReferences collects every reference found across a
+// map of expressions, such as ConfigResource.Expressions or
+// ModuleCall.Expressions.
+func ExpressionMapReferences(exprs map[string]Expression) []string {
+	var refs []string
+	for _, expr := range exprs {
+		refs = append(refs, ExpressionReferences(expr)...)
+	}
+
+	return refs
+}
+
+// ExpressionReferences recursively collects References out of an
+// Expression and any blocks nested within it.
+func ExpressionReferences(e Expression) []string {
+	refs := append([]string{}, e.References...)
+
+	for _, block := range e.NestedBlocks {
+		for _, sub := range block {
+			refs = append(refs, ExpressionReferences(sub)...)
+		}
+	}
+
+	return refs
+}
+
+// ResolveReference turns a raw HCL reference (as found in
+// Expression.References, e.g. "aws_instance.foo.id", "module.x.output",
+// "var.y", "each.key") into an absolute address, relative to the
+// module identified by prefix. It returns "" for references that
+// don't resolve to a graph node, such as "each.key", "count.index",
+// "self", and "terraform.workspace".
+func ResolveReference(prefix, ref string) string {
+	switch {
+	case ref == "each.key", ref == "each.value", ref == "count.index", ref == "self":
+		return ""
+	case strings.HasPrefix(ref, "each.") || strings.HasPrefix(ref, "self.") ||
+		strings.HasPrefix(ref, "path.") || strings.HasPrefix(ref, "terraform."):
+		return ""
+	case strings.HasPrefix(ref, "var.") || strings.HasPrefix(ref, "local."):
+		return JoinAddress(prefix, ref)
+	case strings.HasPrefix(ref, "module."):
+		parts := strings.SplitN(ref, ".", 3)
+		if len(parts) < 2 {
+			return ""
+		}
+
+		return JoinAddress(prefix, parts[0]+"."+parts[1])
+	case strings.HasPrefix(ref, "data."):
+		parts := strings.SplitN(ref, ".", 4)
+		if len(parts) < 3 {
+			return ""
+		}
+
+		return JoinAddress(prefix, strings.Join(parts[:3], "."))
+	default:
+		parts := strings.SplitN(ref, ".", 3)
+		if len(parts) < 2 {
+			return ""
+		}
+
+		return JoinAddress(prefix, parts[0]+"."+parts[1])
+	}
+}
+
+// JoinAddress joins a module address prefix (e.g. "module.x") with an
+// address relative to that module (e.g. "aws_instance.foo"), or
+// returns addr unchanged if prefix is the root module.
+func JoinAddress(prefix, addr string) string {
+	if prefix == "" {
+		return addr
+	}
+
+	return prefix + "." + addr
+}
+
+// StripInstanceKey removes a trailing "[...]" instance key from a
+// resource address, e.g. "aws_instance.foo[0]" becomes
+// "aws_instance.foo".
+func StripInstanceKey(addr string) string {
+	if i := strings.IndexByte(addr, '['); i >= 0 {
+		return addr[:i]
+	}
+
+	return addr
+}
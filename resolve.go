@@ -0,0 +1,133 @@
+package tfjson
+
+import "strings"
+
+// Resolve attempts to compute the concrete value of an expression
+// without involving Terraform itself. It succeeds in four cases:
+//
+//   - The expression is a constant (ConstantValue is set).
+//   - The expression has neither a ConstantValue nor References; it
+//     resolves to nil.
+//   - The expression is a single direct reference (e.g. "var.x" or
+//     "data.aws_ami.foo.id") whose value vars or lookups can supply.
+//   - The expression is a nested block; each key of each block is
+//     resolved recursively, and the whole block resolves only if
+//     every key within it does.
+//
+// vars supplies values for "var." references, keyed by variable name
+// without the "var." prefix. lookups is consulted for any other
+// reference (resource attributes, data sources, module outputs,
+// locals, and so on); it may be nil if no such references are
+// expected to resolve.
+//
+// An expression built from more than one reference, such as a string
+// interpolating two variables, cannot be resolved here: doing so
+// correctly would require the original HCL template, which this
+// package does not retain. Resolve returns false for such
+// expressions rather than guess.
+func (e *Expression) Resolve(vars map[string]interface{}, lookups func(ref string) (interface{}, bool)) (interface{}, bool) {
+	if e == nil {
+		return nil, false
+	}
+
+	if len(e.NestedBlocks) > 0 {
+		blocks := make([]map[string]interface{}, 0, len(e.NestedBlocks))
+		for _, block := range e.NestedBlocks {
+			resolved := make(map[string]interface{}, len(block))
+			for k, sub := range block {
+				sub := sub
+				val, ok := sub.Resolve(vars, lookups)
+				if !ok {
+					return nil, false
+				}
+
+				resolved[k] = val
+			}
+
+			blocks = append(blocks, resolved)
+		}
+
+		return blocks, true
+	}
+
+	if e.ConstantValue != nil {
+		return e.ConstantValue, true
+	}
+
+	switch len(e.References) {
+	case 0:
+		// No constant value and nothing referenced: an explicit null.
+		return nil, true
+	case 1:
+		return resolveReferenceValue(e.References[0], vars, lookups)
+	default:
+		return nil, false
+	}
+}
+
+// resolveReferenceValue resolves a single raw HCL reference (as
+// found in Expression.References) against vars and lookups.
+func resolveReferenceValue(ref string, vars map[string]interface{}, lookups func(ref string) (interface{}, bool)) (interface{}, bool) {
+	if strings.HasPrefix(ref, "var.") {
+		name := strings.TrimPrefix(ref, "var.")
+		if val, ok := vars[name]; ok {
+			return val, true
+		}
+	}
+
+	if lookups != nil {
+		if val, ok := lookups(ref); ok {
+			return val, true
+		}
+	}
+
+	return nil, false
+}
+
+// ResolveAll walks every resource in the module, and recursively into
+// every module reachable through ModuleCalls, resolving each
+// resource's configuration expressions against vars. It returns only
+// the resolvable subset: resources (or individual expression keys)
+// that Resolve could not fold in are simply omitted, rather than
+// causing the whole walk to fail. Resources are keyed by their
+// address, dot-prefixed by the address of the module they are
+// declared in (e.g. "module.network.aws_subnet.main").
+//
+// This enables static analysis - policy checks, cost estimation, and
+// the like - directly against a Config, without spinning up
+// Terraform to evaluate it.
+func (m *ConfigModule) ResolveAll(vars map[string]interface{}) map[string]map[string]interface{} {
+	result := make(map[string]map[string]interface{})
+	if m == nil {
+		return result
+	}
+
+	resolveAllModule(m, "", vars, result)
+	return result
+}
+
+func resolveAllModule(m *ConfigModule, prefix string, vars map[string]interface{}, result map[string]map[string]interface{}) {
+	for _, r := range m.Resources {
+		addr := JoinAddress(prefix, r.Address)
+
+		resolved := make(map[string]interface{}, len(r.Expressions))
+		for key, expr := range r.Expressions {
+			expr := expr
+			if val, ok := expr.Resolve(vars, nil); ok {
+				resolved[key] = val
+			}
+		}
+
+		if len(resolved) > 0 {
+			result[addr] = resolved
+		}
+	}
+
+	for name, call := range m.ModuleCalls {
+		if call.Module == nil {
+			continue
+		}
+
+		resolveAllModule(call.Module, JoinAddress(prefix, "module."+name), vars, result)
+	}
+}
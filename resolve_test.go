@@ -0,0 +1,119 @@
+package tfjson
+
+import "testing"
+
+func TestExpressionResolveConstant(t *testing.T) {
+	e := &Expression{ConstantValue: "ami-123"}
+
+	val, ok := e.Resolve(nil, nil)
+	if !ok || val != "ami-123" {
+		t.Fatalf("got (%v, %v), want (ami-123, true)", val, ok)
+	}
+}
+
+func TestExpressionResolveVar(t *testing.T) {
+	e := &Expression{References: []string{"var.region"}}
+
+	val, ok := e.Resolve(map[string]interface{}{"region": "us-east-1"}, nil)
+	if !ok || val != "us-east-1" {
+		t.Fatalf("got (%v, %v), want (us-east-1, true)", val, ok)
+	}
+}
+
+func TestExpressionResolveLookup(t *testing.T) {
+	e := &Expression{References: []string{"data.aws_ami.foo.id"}}
+
+	lookups := func(ref string) (interface{}, bool) {
+		if ref == "data.aws_ami.foo.id" {
+			return "ami-456", true
+		}
+		return nil, false
+	}
+
+	val, ok := e.Resolve(nil, lookups)
+	if !ok || val != "ami-456" {
+		t.Fatalf("got (%v, %v), want (ami-456, true)", val, ok)
+	}
+}
+
+func TestExpressionResolveMultiReferenceFails(t *testing.T) {
+	e := &Expression{References: []string{"var.a", "var.b"}}
+
+	_, ok := e.Resolve(map[string]interface{}{"a": "x", "b": "y"}, nil)
+	if ok {
+		t.Fatal("expected a multi-reference expression to be unresolvable")
+	}
+}
+
+func TestExpressionResolveNestedBlock(t *testing.T) {
+	e := &Expression{
+		NestedBlocks: []map[string]Expression{
+			{
+				"from_port": {ConstantValue: float64(80)},
+				"to_port":   {ConstantValue: float64(80)},
+			},
+		},
+	}
+
+	val, ok := e.Resolve(nil, nil)
+	if !ok {
+		t.Fatal("expected nested block to resolve")
+	}
+
+	blocks, ok := val.([]map[string]interface{})
+	if !ok || len(blocks) != 1 {
+		t.Fatalf("got %+v, want a single resolved block", val)
+	}
+	if blocks[0]["from_port"] != float64(80) || blocks[0]["to_port"] != float64(80) {
+		t.Fatalf("got %+v, want from_port/to_port == 80", blocks[0])
+	}
+}
+
+func TestConfigModuleResolveAll(t *testing.T) {
+	m := &ConfigModule{
+		Resources: []ConfigResource{
+			{
+				Address: "aws_instance.web",
+				Expressions: map[string]Expression{
+					"ami":       {ConstantValue: "ami-123"},
+					"subnet_id": {References: []string{"aws_subnet.main.id"}},
+				},
+			},
+		},
+		ModuleCalls: map[string]ModuleCall{
+			"network": {
+				Module: &ConfigModule{
+					Resources: []ConfigResource{
+						{
+							Address: "aws_subnet.main",
+							Expressions: map[string]Expression{
+								"cidr_block": {ConstantValue: "10.0.0.0/24"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resolved := m.ResolveAll(nil)
+
+	root, ok := resolved["aws_instance.web"]
+	if !ok {
+		t.Fatalf("got %+v, missing aws_instance.web", resolved)
+	}
+	if root["ami"] != "ami-123" {
+		t.Fatalf("got ami %v, want ami-123", root["ami"])
+	}
+	if _, ok := root["subnet_id"]; ok {
+		t.Fatalf("expected subnet_id to be omitted as unresolvable, got %+v", root)
+	}
+
+	nested, ok := resolved["module.network.aws_subnet.main"]
+	if !ok {
+		t.Fatalf("got %+v, missing module.network.aws_subnet.main", resolved)
+	}
+	if nested["cidr_block"] != "10.0.0.0/24" {
+		t.Fatalf("got cidr_block %v, want 10.0.0.0/24", nested["cidr_block"])
+	}
+}